@@ -1,17 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/frizz925/higuchi/internal/acl"
+	"github.com/frizz925/higuchi/internal/admin"
 	"github.com/frizz925/higuchi/internal/auth"
 	"github.com/frizz925/higuchi/internal/config"
 	"github.com/frizz925/higuchi/internal/crypto/hasher"
 	"github.com/frizz925/higuchi/internal/dispatcher"
 	"github.com/frizz925/higuchi/internal/filter"
+	"github.com/frizz925/higuchi/internal/metrics"
 	"github.com/frizz925/higuchi/internal/pool"
 	"github.com/frizz925/higuchi/internal/server"
 	"github.com/frizz925/higuchi/internal/worker"
@@ -31,71 +38,157 @@ var serveCmd = &cobra.Command{
 	},
 }
 
-func runServe() error {
-	cfg, err := config.ReadConfig()
-	if err != nil {
-		return fmt.Errorf("error while reading config: %v", err)
+func authCompare(password string, i interface{}) bool {
+	switch v := i.(type) {
+	case string:
+		return password == v
+	case hasher.Argon2Digest:
+		return v.Compare(password) == 0
 	}
-	cfa, cfc := cfg.Filters.Auth, cfg.Filters.Certbot
+	return false
+}
+
+// buildPool reads cfg's filter settings and returns a freshly built
+// worker pool, wired the same way for both the initial startup and
+// every admin-triggered reload. collector is reused across reloads
+// rather than rebuilt, since a Prometheus collector owns its own
+// registry and can't be registered twice. The returned enforcer (nil
+// if ACL is disabled) owns a filesystem watcher goroutine; the caller
+// is responsible for Close()-ing the previous one after a reload swaps
+// it out, the same way it swaps the pool.
+func buildPool(ctx context.Context, cfg *config.Config, logger *zap.Logger, collector metrics.Collector) (pool.Pool, *acl.Enforcer, error) {
+	cfa, cfc, cfo, cfl := cfg.Filters.Auth, cfg.Filters.Certbot, cfg.Filters.OIDC, cfg.Filters.ACL
 
 	pepper, err := cfa.Pepper()
 	if err != nil {
-		return fmt.Errorf("error while decoding pepper: %v", err)
+		return nil, nil, fmt.Errorf("error while decoding pepper: %v", err)
 	}
 
-	authCompare := func(password string, i interface{}) bool {
-		switch v := i.(type) {
-		case string:
-			return password == v
-		case hasher.Argon2Digest:
-			return v.Compare(password) == 0
-		}
-		return false
-	}
 	users := make(map[string]interface{})
-
-	if cfg.Filters.Auth.Enabled {
+	if cfa.Enabled {
 		h := hasher.NewArgon2Hasher(pepper)
 		aa := auth.NewArgon2Auth(h)
 		au, err := aa.ReadPasswordsFile(cfa.PasswordsFile)
 		if err != nil {
-			return fmt.Errorf("error while reading passwords file: %v", err)
+			return nil, nil, fmt.Errorf("error while reading passwords file: %v", err)
 		}
 		for user, ad := range au {
 			users[user] = ad
 		}
 	}
 
-	var logger *zap.Logger
+	// OIDC provider discovery runs once up front; the resulting filter
+	// (whose provider fetches and caches the signing keys) is then shared
+	// by every worker, same as the basic-auth users map above.
+	var oidcFilter filter.HTTPFilter
+	if cfo.Enabled {
+		oidcFilter, err = filter.NewOIDCFilter(ctx, filter.OIDCConfig{
+			Issuer:         cfo.Issuer,
+			Audience:       cfo.Audience,
+			ClientID:       cfo.ClientID,
+			RequiredClaims: cfo.RequiredClaims,
+			Scopes:         cfo.Scopes,
+			MaxCacheTTL:    time.Duration(cfo.MaxCacheTTLSeconds) * time.Second,
+		}, collector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while setting up OIDC filter: %v", err)
+		}
+	}
+
+	var enforcer *acl.Enforcer
+	if cfl.Enabled {
+		enforcer, err = acl.New(cfl.ModelPath, cfl.PolicyPath, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while setting up ACL enforcer: %v", err)
+		}
+	}
+
+	rules := make([]dispatcher.UpstreamRule, len(cfg.Upstream.Rules))
+	for i, r := range cfg.Upstream.Rules {
+		rules[i] = dispatcher.UpstreamRule{Pattern: r.Pattern, URL: r.URL}
+	}
+	d, err := dispatcher.NewChainedDispatcher(dispatcher.UpstreamConfig{
+		URL:     cfg.Upstream.URL,
+		NoProxy: cfg.Upstream.NoProxy,
+		Rules:   rules,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while setting up upstream dispatcher: %v", err)
+	}
+	df := filter.NewDispatchFilter(d)
+
+	poolSize := cfg.Worker.PoolSize
+	if poolSize <= 0 {
+		poolSize = 1024
+	}
+
+	wp := pool.NewPreallocatedPool(func(num int) *worker.Worker {
+		hfs := make([]filter.HTTPFilter, 0)
+		if cfc.Enabled {
+			hfs = append(hfs, filter.NewCertbotFilter(cfc.Hostname, cfc.Webroot))
+		}
+		switch {
+		case cfo.Enabled:
+			hfs = append(hfs, oidcFilter)
+		case cfa.Enabled:
+			hfs = append(hfs, filter.NewAuthFilter(users, authCompare, collector))
+		}
+		if cfl.Enabled {
+			hfs = append(hfs, filter.NewACLFilter(enforcer))
+		}
+		hfs = append(
+			hfs,
+			filter.NewTunnelFilter(df, cfg.Worker.BufferSize, collector),
+			filter.NewForwardFilter(df, collector),
+		)
+		return worker.New(num, filter.NewParseFilter(collector, hfs...), collector, logger)
+	}, poolSize)
+	return wp, enforcer, nil
+}
+
+func runServe() error {
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return fmt.Errorf("error while reading config: %v", err)
+	}
+
+	var zapCfg zap.Config
 	switch cfg.Logger.Mode {
 	case "production":
-		logger, err = zap.NewProduction()
+		zapCfg = zap.NewProductionConfig()
 	default:
-		logger, err = zap.NewDevelopment()
+		zapCfg = zap.NewDevelopmentConfig()
+	}
+	if s := cfg.Logger.Sampling; s != nil {
+		zapCfg.Sampling = &zap.SamplingConfig{Initial: s.Initial, Thereafter: s.Thereafter}
 	}
+	logger, err := zapCfg.Build()
 	if err != nil {
 		return err
 	}
 	defer logger.Sync()
 
+	var collector metrics.Collector = metrics.NoopCollector{}
+	var promCollector *metrics.PrometheusCollector
+	if cfg.Metrics.Enabled {
+		promCollector = metrics.NewPrometheusCollector()
+		collector = promCollector
+		if cfg.Metrics.Push.Enabled {
+			interval := time.Duration(cfg.Metrics.Push.IntervalSeconds) * time.Second
+			pusher := metrics.NewPusher(promCollector, cfg.Metrics.Push.Endpoint, interval, logger)
+			go pusher.Run(context.Background())
+		}
+	}
+
+	wp, enforcer, err := buildPool(context.Background(), cfg, logger, collector)
+	if err != nil {
+		return err
+	}
+
 	s := server.New(server.Config{
-		Logger: logger,
-		Pool: pool.NewPreallocatedPool(func(num int) *worker.Worker {
-			hfs := make([]filter.HTTPFilter, 0)
-			if cfg.Filters.Certbot.Enabled {
-				hfs = append(hfs, filter.NewCertbotFilter(cfc.Hostname, cfc.Webroot))
-			}
-			if cfg.Filters.Auth.Enabled {
-				hfs = append(hfs, filter.NewAuthFilter(users, authCompare))
-			}
-			df := filter.NewDispatchFilter(dispatcher.NewTCPDispatcher(cfg.Worker.BufferSize))
-			hfs = append(
-				hfs,
-				filter.NewTunnelFilter(cfg.Worker.BufferSize),
-				filter.NewForwardFilter(df),
-			)
-			return worker.New(num, filter.NewParseFilter(hfs...))
-		}, 1024),
+		Logger:  logger,
+		Pool:    wp,
+		Metrics: collector,
 	})
 
 	for _, addr := range cfg.Server.Listeners {
@@ -107,11 +200,66 @@ func runServe() error {
 		if _, err := s.Listen(network, addr); err != nil {
 			return err
 		}
-		logger.Info(fmt.Sprintf("Higuchi listening at %s", addr))
+		logger.Info("higuchi listening", zap.String("addr", addr), zap.String("network", network))
+	}
+
+	// currentCfg and enforcer are read/written by concurrent admin
+	// requests (Snapshot readers, Reload writers), so both need a lock
+	// rather than s.poolMu, which only guards the pool.
+	var currentCfgMu sync.RWMutex
+	currentCfg := cfg
+	var adminSrv *admin.Server
+	if cfg.Admin.Enabled {
+		deps := admin.Deps{
+			Config: &cfg.Admin,
+			Snapshot: func() *config.Config {
+				currentCfgMu.RLock()
+				defer currentCfgMu.RUnlock()
+				return currentCfg
+			},
+			Reload: func() (*config.Config, error) {
+				newCfg, err := config.ReadConfig()
+				if err != nil {
+					return nil, fmt.Errorf("error while reading config: %v", err)
+				}
+				newPool, newEnforcer, err := buildPool(context.Background(), newCfg, logger, collector)
+				if err != nil {
+					return nil, err
+				}
+				s.SetPool(newPool)
+				currentCfgMu.Lock()
+				currentCfg = newCfg
+				oldEnforcer := enforcer
+				enforcer = newEnforcer
+				currentCfgMu.Unlock()
+				if oldEnforcer != nil {
+					if err := oldEnforcer.Close(); err != nil {
+						logger.Warn("error while closing previous ACL enforcer", zap.Error(err))
+					}
+				}
+				return newCfg, nil
+			},
+			Proxy:  s,
+			Logger: logger,
+		}
+		if promCollector != nil {
+			deps.MetricsHandler = promCollector.Handler()
+		}
+		adminSrv = admin.New(deps)
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("admin API server error", zap.Error(err))
+			}
+		}()
+		logger.Info("higuchi admin api listening", zap.String("addr", cfg.Admin.Address))
 	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	<-sigCh
+
+	if adminSrv != nil {
+		adminSrv.Close()
+	}
 	return s.Close()
 }
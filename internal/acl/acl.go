@@ -0,0 +1,120 @@
+// Package acl wraps a Casbin enforcer that decides whether an
+// authenticated user may reach a given destination. It is shared by
+// filter.ACLFilter and, potentially, the admin API, so it lives on its
+// own rather than inside the filter package.
+package acl
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Enforcer wraps a Casbin enforcer with an RWMutex guarding it, and
+// hot-reloads the enforcer whenever its policy file changes on disk.
+type Enforcer struct {
+	mu       sync.RWMutex
+	enforcer *casbin.Enforcer
+
+	modelPath  string
+	policyPath string
+	logger     *zap.Logger
+	watcher    *fsnotify.Watcher
+}
+
+// New loads a Casbin enforcer from modelPath/policyPath and starts
+// watching policyPath for changes.
+func New(modelPath, policyPath string, logger *zap.Logger) (*Enforcer, error) {
+	e, err := casbin.NewEnforcer(modelPath, policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading casbin policy: %v", err)
+	}
+
+	enf := &Enforcer{enforcer: e, modelPath: modelPath, policyPath: policyPath, logger: logger}
+	if err := enf.watch(); err != nil {
+		return nil, err
+	}
+	return enf, nil
+}
+
+// watch watches the policy file's containing directory, rather than the
+// file itself. fsnotify watches are registered against the underlying
+// inode, so a watch on the file alone stops reporting anything once the
+// file is removed or renamed away -- which is exactly what happens when
+// an editor does a write-then-rename or a plain `mv` replaces the file.
+// Watching the directory and filtering by name survives both. Only
+// events on the policy file's own path trigger a reload, so a change
+// made by swapping a different entry in the directory (such as the
+// "..data" symlink of a Kubernetes ConfigMap mount) is not picked up.
+func (e *Enforcer) watch() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error while creating policy file watcher: %v", err)
+	}
+	if err := w.Add(filepath.Dir(e.policyPath)); err != nil {
+		w.Close()
+		return fmt.Errorf("error while watching policy directory: %v", err)
+	}
+	e.watcher = w
+	go e.watchLoop()
+	return nil
+}
+
+func (e *Enforcer) watchLoop() {
+	for {
+		select {
+		case ev, ok := <-e.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Name != e.policyPath {
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := e.Reload(); err != nil {
+				e.logger.Error("error while reloading ACL policy", zap.Error(err))
+				continue
+			}
+			e.logger.Info("ACL policy reloaded", zap.String("path", e.policyPath))
+		case err, ok := <-e.watcher.Errors:
+			if !ok {
+				return
+			}
+			e.logger.Error("ACL policy watcher error", zap.Error(err))
+		}
+	}
+}
+
+// Reload re-reads the model and policy files from disk and, on success,
+// swaps in the freshly built enforcer.
+func (e *Enforcer) Reload() error {
+	enforcer, err := casbin.NewEnforcer(e.modelPath, e.policyPath)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.enforcer = enforcer
+	e.mu.Unlock()
+	return nil
+}
+
+// Enforce reports whether subject may perform action on object.
+func (e *Enforcer) Enforce(subject, object, action string) (bool, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enforcer.Enforce(subject, object, action)
+}
+
+// Close stops watching the policy file for changes.
+func (e *Enforcer) Close() error {
+	if e.watcher == nil {
+		return nil
+	}
+	return e.watcher.Close()
+}
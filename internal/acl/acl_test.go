@@ -0,0 +1,106 @@
+package acl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+
+func newTestEnforcer(t *testing.T, policy string) *Enforcer {
+	t.Helper()
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+	if err := os.WriteFile(modelPath, []byte(testModel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(modelPath, policyPath, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func TestEnforcerEnforce(t *testing.T) {
+	e := newTestEnforcer(t, "p, alice, example.com:443, CONNECT\n")
+
+	allowed, err := e.Enforce("alice", "example.com:443", "CONNECT")
+	if err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+	if !allowed {
+		t.Error("expected alice to be allowed to CONNECT to example.com:443")
+	}
+
+	denied, err := e.Enforce("bob", "example.com:443", "CONNECT")
+	if err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+	if denied {
+		t.Error("expected bob to be denied, no matching policy for bob")
+	}
+
+	wrongAction, err := e.Enforce("alice", "example.com:443", "GET")
+	if err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+	if wrongAction {
+		t.Error("expected alice's CONNECT grant not to cover GET")
+	}
+}
+
+func TestEnforcerReload(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+	if err := os.WriteFile(modelPath, []byte(testModel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte("p, alice, example.com:443, CONNECT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(modelPath, policyPath, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer e.Close()
+
+	if allowed, _ := e.Enforce("bob", "example.com:443", "CONNECT"); allowed {
+		t.Fatal("bob should not be allowed before the policy is reloaded")
+	}
+
+	if err := os.WriteFile(policyPath, []byte("p, bob, example.com:443, CONNECT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	allowed, err := e.Enforce("bob", "example.com:443", "CONNECT")
+	if err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+	if !allowed {
+		t.Error("expected bob to be allowed after Reload picked up the new policy")
+	}
+}
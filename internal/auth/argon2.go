@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frizz925/higuchi/internal/crypto/hasher"
+)
+
+// Argon2Auth loads and verifies passwords stored as Argon2 digests.
+type Argon2Auth struct {
+	hasher *hasher.Argon2Hasher
+}
+
+// NewArgon2Auth returns an Argon2Auth that parses digests using h.
+func NewArgon2Auth(h *hasher.Argon2Hasher) *Argon2Auth {
+	return &Argon2Auth{hasher: h}
+}
+
+// ReadPasswordsFile reads a "user:salt:hash" passwords file, one entry per
+// line, and returns the parsed digests keyed by username. Blank lines and
+// lines starting with "#" are ignored.
+func (a *Argon2Auth) ReadPasswordsFile(path string) (map[string]hasher.Argon2Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]hasher.Argon2Digest)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed passwords line: %q", line)
+		}
+		digest, err := a.hasher.ParseDigest(rest)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing digest for user %q: %v", user, err)
+		}
+		users[user] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,85 @@
+package dispatcher
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// ChainedDispatcher forwards outbound connections through an upstream
+// HTTP CONNECT or SOCKS5 proxy, honoring a NoProxy list that falls back
+// to a direct net.Dial, and optional per-destination rules that pick a
+// different upstream (or none) for matching hosts.
+type ChainedDispatcher struct {
+	upstream Dispatcher
+	noProxy  []noProxyEntry
+	rules    []chainRule
+}
+
+type chainRule struct {
+	host     noProxyEntry
+	upstream Dispatcher
+}
+
+// NewChainedDispatcher builds a Dispatcher from cfg. An empty cfg.URL
+// means connections are dialed directly, same as TCPDispatcher.
+func NewChainedDispatcher(cfg UpstreamConfig) (Dispatcher, error) {
+	upstream, err := newProxyDispatcher(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error while building upstream dispatcher: %v", err)
+	}
+	noProxy, err := parseNoProxy(cfg.NoProxy)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing no_proxy list: %v", err)
+	}
+
+	rules := make([]chainRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		d, err := newProxyDispatcher(r.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error while building dispatcher for rule %q: %v", r.Pattern, err)
+		}
+		entries, err := parseNoProxy([]string{r.Pattern})
+		if err != nil || len(entries) == 0 {
+			return nil, fmt.Errorf("error while parsing rule pattern %q: %v", r.Pattern, err)
+		}
+		rules = append(rules, chainRule{host: entries[0], upstream: d})
+	}
+
+	return &ChainedDispatcher{upstream: upstream, noProxy: noProxy, rules: rules}, nil
+}
+
+func (d *ChainedDispatcher) Dial(network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	if matchesNoProxy(d.noProxy, host) {
+		return net.Dial(network, addr)
+	}
+	for _, r := range d.rules {
+		if matchesNoProxy([]noProxyEntry{r.host}, host) {
+			return r.upstream.Dial(network, addr)
+		}
+	}
+	return d.upstream.Dial(network, addr)
+}
+
+func newProxyDispatcher(rawURL string) (Dispatcher, error) {
+	if rawURL == "" {
+		return NewTCPDispatcher(0), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing upstream URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return NewHTTPConnectDispatcher(u), nil
+	case "socks5":
+		return NewSOCKS5Dispatcher(u), nil
+	default:
+		return nil, fmt.Errorf("unsupported upstream scheme: %q", u.Scheme)
+	}
+}
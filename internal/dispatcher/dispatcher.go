@@ -0,0 +1,9 @@
+package dispatcher
+
+import "net"
+
+// Dispatcher dials outbound connections to a proxied destination on
+// behalf of a filter such as filter.TunnelFilter or filter.ForwardFilter.
+type Dispatcher interface {
+	Dial(network, addr string) (net.Conn, error)
+}
@@ -0,0 +1,104 @@
+package dispatcher
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// fakeHTTPProxy accepts a single connection, reads the CONNECT request
+// off it, and replies with status. Any extra bytes in reply are written
+// right after the CONNECT response, in the same write, simulating an
+// upstream that pushes the destination's first bytes alongside it.
+func fakeHTTPProxy(t *testing.T, status string, extra []byte) (addr string, gotAuth chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	gotAuth = make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			gotAuth <- ""
+			return
+		}
+		gotAuth <- req.Header.Get("Proxy-Authorization")
+		reply := append([]byte("HTTP/1.1 "+status+"\r\n\r\n"), extra...)
+		conn.Write(reply)
+	}()
+	return l.Addr().String(), gotAuth
+}
+
+func TestHTTPConnectDispatcherDial(t *testing.T) {
+	addr, gotAuth := fakeHTTPProxy(t, "200 Connection Established", nil)
+	proxyURL, _ := url.Parse("http://user:pass@" + addr)
+	d := NewHTTPConnectDispatcher(proxyURL)
+
+	conn, err := d.Dial("tcp", "example.com:443")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	if auth := <-gotAuth; auth != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Proxy-Authorization = %q, want Basic-encoded user:pass", auth)
+	}
+}
+
+func TestHTTPConnectDispatcherDialRefused(t *testing.T) {
+	addr, _ := fakeHTTPProxy(t, "407 Proxy Authentication Required", nil)
+	proxyURL, _ := url.Parse("http://" + addr)
+	d := NewHTTPConnectDispatcher(proxyURL)
+
+	if _, err := d.Dial("tcp", "example.com:443"); err == nil {
+		t.Error("expected Dial() to fail for a non-200 CONNECT response")
+	}
+}
+
+func TestHTTPConnectDispatcherPreservesBufferedBytes(t *testing.T) {
+	extra := []byte("leftover-bytes")
+	addr, _ := fakeHTTPProxy(t, "200 Connection Established", extra)
+	proxyURL, _ := url.Parse("http://" + addr)
+	d := NewHTTPConnectDispatcher(proxyURL)
+
+	conn, err := d.Dial("tcp", "example.com:443")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	got := make([]byte, len(extra))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("error reading buffered bytes: %v", err)
+	}
+	if string(got) != string(extra) {
+		t.Errorf("read %q, want %q", got, extra)
+	}
+}
+
+func TestNewHTTPConnectDispatcherTLSConfig(t *testing.T) {
+	httpURL, _ := url.Parse("http://host:3128")
+	if d := NewHTTPConnectDispatcher(httpURL); d.tlsConfig != nil {
+		t.Error("expected no tlsConfig for an http:// upstream")
+	}
+
+	httpsURL, _ := url.Parse("https://host:3129")
+	d := NewHTTPConnectDispatcher(httpsURL)
+	if d.tlsConfig == nil {
+		t.Fatal("expected a tlsConfig for an https:// upstream")
+	}
+	if d.tlsConfig.ServerName != "host" {
+		t.Errorf("tlsConfig.ServerName = %q, want %q", d.tlsConfig.ServerName, "host")
+	}
+}
@@ -0,0 +1,63 @@
+package dispatcher
+
+import "testing"
+
+func TestMatchesNoProxy(t *testing.T) {
+	entries, err := parseNoProxy([]string{"10.0.0.0/8", "example.com", ".internal.example"})
+	if err != nil {
+		t.Fatalf("parseNoProxy() error = %v", err)
+	}
+
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.1", false},
+		{"example.com", true},
+		{"other.com", false},
+		{"api.internal.example", true},
+		{"internal.example", false},
+	}
+	for _, c := range cases {
+		if got := matchesNoProxy(entries, c.host); got != c.want {
+			t.Errorf("matchesNoProxy(%q) = %v, want %v", c.host, got, c.want)
+		}
+	}
+}
+
+func TestParseNoProxySkipsBlank(t *testing.T) {
+	entries, err := parseNoProxy([]string{"", "  ", "example.com"})
+	if err != nil {
+		t.Fatalf("parseNoProxy() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("len(entries) = %d, want 1", len(entries))
+	}
+}
+
+func TestChainedDispatcherRouting(t *testing.T) {
+	d, err := NewChainedDispatcher(UpstreamConfig{
+		NoProxy: []string{"direct.example"},
+		Rules: []UpstreamRule{
+			{Pattern: "ruled.example", URL: ""},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChainedDispatcher() error = %v", err)
+	}
+	cd := d.(*ChainedDispatcher)
+
+	if !matchesNoProxy(cd.noProxy, "direct.example") {
+		t.Error("expected direct.example to match the no_proxy list")
+	}
+	if len(cd.rules) != 1 || !matchesNoProxy([]noProxyEntry{cd.rules[0].host}, "ruled.example") {
+		t.Error("expected ruled.example to match the configured rule")
+	}
+}
+
+func TestNewChainedDispatcherRejectsUnsupportedScheme(t *testing.T) {
+	if _, err := NewChainedDispatcher(UpstreamConfig{URL: "ftp://host:21"}); err == nil {
+		t.Error("expected an unsupported upstream scheme to be rejected")
+	}
+}
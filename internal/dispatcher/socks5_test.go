@@ -0,0 +1,157 @@
+package dispatcher
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"testing"
+)
+
+// fakeSOCKS5Result records what fakeSOCKS5Proxy saw during a handshake.
+type fakeSOCKS5Result struct {
+	method byte
+	user   string
+	pass   string
+	dest   string
+}
+
+// fakeSOCKS5Proxy accepts a single connection and runs through the
+// greeting (requiring auth when wantAuth is set), optional
+// username/password exchange, and CONNECT reply, recording what it saw.
+func fakeSOCKS5Proxy(t *testing.T, wantAuth bool) (addr string, result chan fakeSOCKS5Result) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	result = make(chan fakeSOCKS5Result, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var res fakeSOCKS5Result
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(conn, head); err != nil {
+			return
+		}
+		methods := make([]byte, head[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return
+		}
+
+		selected := byte(socks5AuthNone)
+		if wantAuth {
+			selected = socks5AuthUserPass
+		}
+		conn.Write([]byte{socks5Version, selected})
+		res.method = selected
+
+		if wantAuth {
+			authHead := make([]byte, 2)
+			if _, err := io.ReadFull(conn, authHead); err != nil {
+				return
+			}
+			user := make([]byte, authHead[1])
+			if _, err := io.ReadFull(conn, user); err != nil {
+				return
+			}
+			passLen := make([]byte, 1)
+			if _, err := io.ReadFull(conn, passLen); err != nil {
+				return
+			}
+			pass := make([]byte, passLen[0])
+			if _, err := io.ReadFull(conn, pass); err != nil {
+				return
+			}
+			res.user, res.pass = string(user), string(pass)
+			conn.Write([]byte{0x01, 0x00})
+		}
+
+		reqHead := make([]byte, 5)
+		if _, err := io.ReadFull(conn, reqHead); err != nil {
+			return
+		}
+		host := make([]byte, reqHead[4])
+		if _, err := io.ReadFull(conn, host); err != nil {
+			return
+		}
+		port := make([]byte, 2)
+		if _, err := io.ReadFull(conn, port); err != nil {
+			return
+		}
+		res.dest = string(host)
+		result <- res
+
+		conn.Write([]byte{socks5Version, 0x00, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	}()
+	return l.Addr().String(), result
+}
+
+func TestSOCKS5DispatcherDialNoAuth(t *testing.T) {
+	addr, result := fakeSOCKS5Proxy(t, false)
+	proxyURL, _ := url.Parse("socks5://" + addr)
+	d := NewSOCKS5Dispatcher(proxyURL)
+
+	conn, err := d.Dial("tcp", "example.com:443")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	res := <-result
+	if res.method != socks5AuthNone {
+		t.Errorf("method = %x, want no-auth", res.method)
+	}
+	if res.dest != "example.com" {
+		t.Errorf("dest = %q, want %q", res.dest, "example.com")
+	}
+}
+
+func TestSOCKS5DispatcherDialWithAuth(t *testing.T) {
+	addr, result := fakeSOCKS5Proxy(t, true)
+	proxyURL, _ := url.Parse("socks5://alice:s3cret@" + addr)
+	d := NewSOCKS5Dispatcher(proxyURL)
+
+	conn, err := d.Dial("tcp", "internal.example:8080")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	res := <-result
+	if res.user != "alice" || res.pass != "s3cret" {
+		t.Errorf("credentials = %q/%q, want alice/s3cret", res.user, res.pass)
+	}
+}
+
+func TestSOCKS5DispatcherDialRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		head := make([]byte, 2)
+		io.ReadFull(conn, head)
+		methods := make([]byte, head[1])
+		io.ReadFull(conn, methods)
+		conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+	}()
+
+	proxyURL, _ := url.Parse("socks5://" + l.Addr().String())
+	d := NewSOCKS5Dispatcher(proxyURL)
+	if _, err := d.Dial("tcp", "example.com:443"); err == nil {
+		t.Error("expected Dial() to fail when the proxy rejects all auth methods")
+	}
+}
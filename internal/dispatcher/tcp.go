@@ -0,0 +1,19 @@
+package dispatcher
+
+import "net"
+
+// TCPDispatcher dials destinations directly over TCP.
+type TCPDispatcher struct {
+	bufferSize int
+}
+
+// NewTCPDispatcher returns a Dispatcher that dials destinations directly.
+// bufferSize is kept for parity with the tunnel/forward filters that size
+// their copy buffers off the same configuration value.
+func NewTCPDispatcher(bufferSize int) *TCPDispatcher {
+	return &TCPDispatcher{bufferSize: bufferSize}
+}
+
+func (d *TCPDispatcher) Dial(network, addr string) (net.Conn, error) {
+	return net.Dial(network, addr)
+}
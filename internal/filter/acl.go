@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"net/http"
+
+	"github.com/frizz925/higuchi/internal/acl"
+	"go.uber.org/zap"
+)
+
+const anonymousUser = "anonymous"
+
+type aclFilter struct {
+	enforcer *acl.Enforcer
+}
+
+// NewACLFilter authorizes a request against a Casbin enforcer: the
+// subject is the authenticated username (ctx.User, or "anonymous" if
+// unset), the object is the request's destination, and the action is
+// its HTTP method. It must run after an authentication filter so
+// ctx.User is populated by the time it runs.
+func NewACLFilter(enforcer *acl.Enforcer) HTTPFilter {
+	return &aclFilter{enforcer: enforcer}
+}
+
+func (f *aclFilter) Name() string { return "acl" }
+
+func (f *aclFilter) Filter(ctx *Context) (bool, error) {
+	req := ctx.Request
+	subject := ctx.User
+	if subject == "" {
+		subject = anonymousUser
+	}
+	object := req.Host
+	if req.Method != http.MethodConnect {
+		object = req.URL.String()
+	}
+
+	allowed, err := f.enforcer.Enforce(subject, object, req.Method)
+	if err != nil {
+		return false, err
+	}
+	if allowed {
+		return true, nil
+	}
+
+	ctx.Logger.Info("ACL denied request",
+		zap.String("user", subject),
+		zap.String("destination", object),
+		zap.String("method", req.Method),
+	)
+
+	if req.Method == http.MethodConnect {
+		return false, ctx.Conn.Close()
+	}
+	resp := &http.Response{
+		StatusCode: http.StatusForbidden,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    req,
+	}
+	return false, resp.Write(ctx.Conn)
+}
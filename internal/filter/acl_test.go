@@ -0,0 +1,132 @@
+package filter
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frizz925/higuchi/internal/acl"
+	"go.uber.org/zap"
+)
+
+const aclTestModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+
+func newTestACLFilter(t *testing.T, policy string) HTTPFilter {
+	t.Helper()
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+	if err := os.WriteFile(modelPath, []byte(aclTestModel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	enforcer, err := acl.New(modelPath, policyPath, zap.NewNop())
+	if err != nil {
+		t.Fatalf("acl.New() error = %v", err)
+	}
+	t.Cleanup(func() { enforcer.Close() })
+	return NewACLFilter(enforcer)
+}
+
+func newTestContext(t *testing.T, user, method, host string) (*Context, net.Conn) {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	req := &http.Request{
+		Method: method,
+		Host:   host,
+		URL:    &url.URL{Opaque: host},
+		Header: make(http.Header),
+	}
+	return &Context{
+		Conn:    server,
+		Request: req,
+		User:    user,
+		Logger:  zap.NewNop(),
+	}, client
+}
+
+func TestACLFilterAllows(t *testing.T) {
+	f := newTestACLFilter(t, "p, alice, example.com:443, CONNECT\n")
+	ctx, _ := newTestContext(t, "alice", http.MethodConnect, "example.com:443")
+
+	cont, err := f.Filter(ctx)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if !cont {
+		t.Error("expected the chain to continue for an allowed request")
+	}
+}
+
+func TestACLFilterDeniesConnectByClosingConn(t *testing.T) {
+	f := newTestACLFilter(t, "p, alice, example.com:443, CONNECT\n")
+	ctx, client := newTestContext(t, "bob", http.MethodConnect, "example.com:443")
+
+	cont, err := f.Filter(ctx)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if cont {
+		t.Error("expected the chain to stop for a denied request")
+	}
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Error("expected the connection to be closed for a denied CONNECT")
+	}
+}
+
+func TestACLFilterDeniesPlainRequestWith403(t *testing.T) {
+	f := newTestACLFilter(t, "p, alice, http://example.com/, GET\n")
+	ctx, client := newTestContext(t, "bob", http.MethodGet, "example.com")
+	ctx.Request.URL, _ = url.Parse("http://example.com/")
+
+	done := make(chan struct{})
+	var resp *http.Response
+	var readErr error
+	go func() {
+		defer close(done)
+		resp, readErr = http.ReadResponse(bufio.NewReader(client), ctx.Request)
+	}()
+
+	if _, err := f.Filter(ctx); err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	<-done
+	if readErr != nil {
+		t.Fatalf("error reading response: %v", readErr)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestACLFilterAnonymousSubject(t *testing.T) {
+	f := newTestACLFilter(t, "p, anonymous, example.com:443, CONNECT\n")
+	ctx, _ := newTestContext(t, "", http.MethodConnect, "example.com:443")
+
+	cont, err := f.Filter(ctx)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if !cont {
+		t.Error("expected an unauthenticated user to be treated as the anonymous subject")
+	}
+}
@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/frizz925/higuchi/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// AuthCompare compares a plaintext password against a stored credential of
+// unspecified underlying type (a plain string or a hasher.Argon2Digest,
+// for example), returning whether they match.
+type AuthCompare func(password string, stored interface{}) bool
+
+type authFilter struct {
+	users     map[string]interface{}
+	compare   AuthCompare
+	collector metrics.Collector
+}
+
+// NewAuthFilter validates the Proxy-Authorization basic-auth header
+// against users, using compare to check the supplied password against
+// whatever credential type users stores. On success it records the
+// authenticated username on ctx.User.
+func NewAuthFilter(users map[string]interface{}, compare AuthCompare, collector metrics.Collector) HTTPFilter {
+	return &authFilter{users: users, compare: compare, collector: collector}
+}
+
+func (f *authFilter) Name() string { return "auth" }
+
+func (f *authFilter) Filter(ctx *Context) (bool, error) {
+	user, password, ok := parseProxyBasicAuth(ctx.Request)
+	if ok {
+		if stored, found := f.users[user]; found && f.compare(password, stored) {
+			ctx.User = user
+			return true, nil
+		}
+	}
+	f.collector.AuthFailure()
+	ctx.Logger.Info("basic auth failed", zap.String("user", user))
+	return false, writeProxyAuthRequired(ctx)
+}
+
+func parseProxyBasicAuth(req *http.Request) (user, password string, ok bool) {
+	header := req.Header.Get("Proxy-Authorization")
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	user, password, ok = strings.Cut(string(raw), ":")
+	return user, password, ok
+}
+
+func writeProxyAuthRequired(ctx *Context) error {
+	resp := &http.Response{
+		StatusCode: http.StatusProxyAuthRequired,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    ctx.Request,
+	}
+	resp.Header.Set("Proxy-Authenticate", `Basic realm="higuchi"`)
+	return resp.Write(ctx.Conn)
+}
@@ -0,0 +1,70 @@
+package filter
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const certbotChallengePrefix = "/.well-known/acme-challenge/"
+
+type certbotFilter struct {
+	hostname string
+	webroot  string
+}
+
+// NewCertbotFilter serves ACME HTTP-01 challenge files for hostname out of
+// webroot, letting Certbot renew certificates through the proxy's plain
+// HTTP listener.
+func NewCertbotFilter(hostname, webroot string) HTTPFilter {
+	return &certbotFilter{hostname: hostname, webroot: webroot}
+}
+
+func (f *certbotFilter) Name() string { return "certbot" }
+
+func (f *certbotFilter) Filter(ctx *Context) (bool, error) {
+	req := ctx.Request
+	if req.Method != http.MethodGet || req.Host != f.hostname || !strings.HasPrefix(req.URL.Path, certbotChallengePrefix) {
+		return true, nil
+	}
+
+	token := strings.TrimPrefix(req.URL.Path, certbotChallengePrefix)
+
+	resp := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    req,
+	}
+	// The token comes straight from the URL path, so reject anything
+	// that could escape the challenge directory (e.g. "../../etc/passwd")
+	// before it ever reaches the filesystem.
+	file, err := openChallengeFileIfSafe(f.webroot, token)
+	if err != nil {
+		resp.StatusCode = http.StatusNotFound
+		resp.Body = io.NopCloser(strings.NewReader("not found"))
+	} else {
+		defer file.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Body = file
+	}
+	return false, resp.Write(ctx.Conn)
+}
+
+// openChallengeFileIfSafe opens the challenge file named token under
+// webroot/.well-known/acme-challenge, refusing to open anything that
+// falls outside that directory.
+func openChallengeFileIfSafe(webroot, token string) (*os.File, error) {
+	if strings.ContainsAny(token, "/\\") {
+		return nil, os.ErrNotExist
+	}
+	dir := filepath.Join(webroot, ".well-known", "acme-challenge")
+	path := filepath.Join(dir, token)
+	if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(path)
+}
@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenChallengeFileIfSafe(t *testing.T) {
+	webroot := t.TempDir()
+	dir := filepath.Join(webroot, ".well-known", "acme-challenge")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "token123"), []byte("challenge"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(webroot, "secret"), []byte("outside"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		f, err := openChallengeFileIfSafe(webroot, "token123")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f.Close()
+	})
+
+	traversals := []string{
+		"../../secret",
+		"../secret",
+		"..",
+		"sub/token123",
+		"/etc/passwd",
+	}
+	for _, token := range traversals {
+		t.Run(token, func(t *testing.T) {
+			if _, err := openChallengeFileIfSafe(webroot, token); err == nil {
+				t.Errorf("expected openChallengeFileIfSafe(%q) to fail, it did not", token)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package filter
+
+import (
+	"net"
+
+	"github.com/frizz925/higuchi/internal/dispatcher"
+)
+
+// DispatchFilter is not itself a chain stage: it wraps a dispatcher.Dispatcher
+// and is shared by TunnelFilter and ForwardFilter as their dialing
+// strategy, so both honour the same upstream-proxy configuration.
+type DispatchFilter struct {
+	dispatcher dispatcher.Dispatcher
+}
+
+// NewDispatchFilter returns a DispatchFilter that dials through d.
+func NewDispatchFilter(d dispatcher.Dispatcher) *DispatchFilter {
+	return &DispatchFilter{dispatcher: d}
+}
+
+// Dial dials addr over TCP via the underlying dispatcher.
+func (f *DispatchFilter) Dial(addr string) (net.Conn, error) {
+	return f.dispatcher.Dial("tcp", addr)
+}
@@ -0,0 +1,44 @@
+// Package filter implements the chain of HTTPFilter stages that a
+// worker.Worker runs a proxied connection through: parsing the request,
+// authenticating it, and either tunnelling (CONNECT) or forwarding
+// (everything else) it to its destination.
+package filter
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Context carries per-connection state through the filter chain.
+type Context struct {
+	Conn    net.Conn
+	Reader  *bufio.Reader
+	Request *http.Request
+
+	// Listener identifies which of the server's listeners accepted
+	// Conn, for per-listener metrics.
+	Listener string
+
+	// User is set by an authentication filter once the request's
+	// credentials have been verified.
+	User string
+
+	// Logger is scoped to this connection: every field logged through it
+	// (correlation id, remote address, listener) shows up on every line
+	// a filter logs for this request.
+	Logger *zap.Logger
+}
+
+// HTTPFilter processes a single proxied connection. It returns whether
+// the chain should continue to the next filter; false means the
+// connection has already been fully handled (a response was written, a
+// tunnel was spliced, or the connection was closed).
+type HTTPFilter interface {
+	Filter(ctx *Context) (bool, error)
+
+	// Name identifies the filter for per-filter metrics and logging.
+	Name() string
+}
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frizz925/higuchi/internal/metrics"
+	"go.uber.org/zap"
+)
+
+type forwardFilter struct {
+	dispatch  *DispatchFilter
+	collector metrics.Collector
+}
+
+// NewForwardFilter handles everything but CONNECT: it dials the
+// destination through df and forwards the request, relaying the
+// upstream's response back to the client verbatim.
+func NewForwardFilter(df *DispatchFilter, collector metrics.Collector) HTTPFilter {
+	return &forwardFilter{dispatch: df, collector: collector}
+}
+
+func (f *forwardFilter) Name() string { return "forward" }
+
+func (f *forwardFilter) Filter(ctx *Context) (bool, error) {
+	req := ctx.Request
+	if req.Method == http.MethodConnect {
+		return true, nil
+	}
+	start := time.Now()
+
+	upstream, err := f.dispatch.Dial(req.Host)
+	if err != nil {
+		f.collector.RequestCompleted(statusClass(http.StatusBadGateway))
+		ctx.Logger.Info("error while dialing upstream", zap.String("host", req.Host), zap.Error(err))
+		resp := &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Request:    req,
+		}
+		return false, resp.Write(ctx.Conn)
+	}
+	defer upstream.Close()
+
+	if err := req.Write(upstream); err != nil {
+		return false, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(upstream), req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	f.collector.RequestCompleted(statusClass(resp.StatusCode))
+	if ce := ctx.Logger.Check(zap.DebugLevel, "request forwarded"); ce != nil {
+		ce.Write(
+			zap.String("method", req.Method),
+			zap.String("host", req.Host),
+			zap.Int("status", resp.StatusCode),
+			zap.Duration("dur", time.Since(start)),
+		)
+	}
+	return false, resp.Write(ctx.Conn)
+}
+
+func statusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
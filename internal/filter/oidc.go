@@ -0,0 +1,191 @@
+package filter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/frizz925/higuchi/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// OIDCConfig configures the OIDC bearer-token filter.
+type OIDCConfig struct {
+	Issuer         string
+	Audience       string
+	ClientID       string
+	RequiredClaims map[string]string
+	Scopes         []string
+
+	// MaxCacheTTL bounds how long a verified token is cached even if its
+	// own expiry claim is further out.
+	MaxCacheTTL time.Duration
+}
+
+type oidcFilter struct {
+	cfg       OIDCConfig
+	verifier  *oidc.IDTokenVerifier
+	collector metrics.Collector
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedToken
+}
+
+type cachedToken struct {
+	subject string
+	expires time.Time
+}
+
+// NewOIDCFilter validates Proxy-Authorization bearer tokens against an
+// OIDC provider. It fetches the provider's discovery document and JWKS
+// through oidc.Provider, which caches and refreshes signing keys on its
+// own. Verified tokens are additionally cached here, keyed by token
+// hash, so a hot token isn't re-verified on every request. On success
+// the verified subject is recorded on ctx.User, the same field
+// AuthFilter populates, so downstream filters and log fields don't need
+// to know which scheme authenticated the request.
+func NewOIDCFilter(ctx context.Context, cfg OIDCConfig, collector metrics.Collector) (HTTPFilter, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error while discovering OIDC provider: %v", err)
+	}
+	if cfg.MaxCacheTTL <= 0 {
+		cfg.MaxCacheTTL = 5 * time.Minute
+	}
+	return &oidcFilter{
+		cfg:       cfg,
+		verifier:  provider.VerifierContext(ctx, &oidc.Config{ClientID: cfg.ClientID}),
+		collector: collector,
+		cache:     make(map[string]cachedToken),
+	}, nil
+}
+
+func (f *oidcFilter) Name() string { return "oidc" }
+
+func (f *oidcFilter) Filter(ctx *Context) (bool, error) {
+	token, ok := parseProxyBearerToken(ctx.Request)
+	if !ok {
+		f.collector.AuthFailure()
+		ctx.Logger.Info("oidc auth failed: missing bearer token")
+		return false, writeProxyBearerRequired(ctx)
+	}
+
+	subject, ok := f.lookupCache(token)
+	if !ok {
+		var err error
+		subject, err = f.verify(ctx.Request.Context(), token)
+		if err != nil {
+			f.collector.AuthFailure()
+			ctx.Logger.Info("oidc auth failed", zap.Error(err))
+			return false, writeProxyBearerRequired(ctx)
+		}
+	}
+
+	ctx.User = subject
+	return true, nil
+}
+
+func (f *oidcFilter) verify(reqCtx context.Context, token string) (string, error) {
+	idToken, err := f.verifier.Verify(reqCtx, token)
+	if err != nil {
+		return "", fmt.Errorf("error while verifying token: %v", err)
+	}
+	if f.cfg.Audience != "" && !containsString(idToken.Audience, f.cfg.Audience) {
+		return "", fmt.Errorf("token audience does not include %q", f.cfg.Audience)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return "", fmt.Errorf("error while decoding claims: %v", err)
+	}
+	for claim, want := range f.cfg.RequiredClaims {
+		if got, _ := claims[claim].(string); got != want {
+			return "", fmt.Errorf("required claim %q not satisfied", claim)
+		}
+	}
+	if len(f.cfg.Scopes) > 0 && !hasRequiredScopes(claims, f.cfg.Scopes) {
+		return "", fmt.Errorf("token missing required scopes")
+	}
+
+	ttl := time.Until(idToken.Expiry)
+	if ttl > f.cfg.MaxCacheTTL {
+		ttl = f.cfg.MaxCacheTTL
+	}
+	f.storeCache(token, idToken.Subject, ttl)
+	return idToken.Subject, nil
+}
+
+func (f *oidcFilter) lookupCache(token string) (string, bool) {
+	key := hashToken(token)
+	f.cacheMu.Lock()
+	defer f.cacheMu.Unlock()
+	entry, ok := f.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(f.cache, key)
+		return "", false
+	}
+	return entry.subject, true
+}
+
+func (f *oidcFilter) storeCache(token, subject string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	key := hashToken(token)
+	f.cacheMu.Lock()
+	defer f.cacheMu.Unlock()
+	f.cache[key] = cachedToken{subject: subject, expires: time.Now().Add(ttl)}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseProxyBearerToken(req *http.Request) (string, bool) {
+	header := req.Header.Get("Proxy-Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(header, prefix), true
+}
+
+func writeProxyBearerRequired(ctx *Context) error {
+	resp := &http.Response{
+		StatusCode: http.StatusProxyAuthRequired,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    ctx.Request,
+	}
+	resp.Header.Set("Proxy-Authenticate", `Bearer realm="higuchi"`)
+	return resp.Write(ctx.Conn)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRequiredScopes(claims map[string]interface{}, required []string) bool {
+	scopeStr, _ := claims["scope"].(string)
+	got := strings.Fields(scopeStr)
+	for _, want := range required {
+		if !containsString(got, want) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package filter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseProxyBearerToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   string
+		wantOk bool
+	}{
+		{"valid bearer", "Bearer abc.def.ghi", "abc.def.ghi", true},
+		{"missing header", "", "", false},
+		{"wrong scheme", "Basic dXNlcjpwYXNz", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := &http.Request{Header: make(http.Header)}
+			if c.header != "" {
+				req.Header.Set("Proxy-Authorization", c.header)
+			}
+			got, ok := parseProxyBearerToken(req)
+			if got != c.want || ok != c.wantOk {
+				t.Errorf("parseProxyBearerToken() = (%q, %v), want (%q, %v)", got, ok, c.want, c.wantOk)
+			}
+		})
+	}
+}
+
+func TestHasRequiredScopes(t *testing.T) {
+	claims := map[string]interface{}{"scope": "openid profile proxy:connect"}
+
+	if !hasRequiredScopes(claims, []string{"proxy:connect"}) {
+		t.Error("expected a satisfied scope requirement to pass")
+	}
+	if !hasRequiredScopes(claims, nil) {
+		t.Error("expected no scope requirement to always pass")
+	}
+	if hasRequiredScopes(claims, []string{"proxy:admin"}) {
+		t.Error("expected a missing scope to fail")
+	}
+	if hasRequiredScopes(map[string]interface{}{}, []string{"proxy:connect"}) {
+		t.Error("expected a missing scope claim entirely to fail")
+	}
+}
+
+func TestOIDCFilterCacheRoundTrip(t *testing.T) {
+	f := &oidcFilter{cache: make(map[string]cachedToken)}
+
+	if _, ok := f.lookupCache("tok"); ok {
+		t.Fatal("expected a cache miss before anything is stored")
+	}
+
+	f.storeCache("tok", "alice", time.Minute)
+	subject, ok := f.lookupCache("tok")
+	if !ok || subject != "alice" {
+		t.Fatalf("lookupCache() = (%q, %v), want (\"alice\", true)", subject, ok)
+	}
+
+	f.storeCache("expired", "bob", -time.Second)
+	if _, ok := f.lookupCache("expired"); ok {
+		t.Error("expected a non-positive TTL not to be cached")
+	}
+}
+
+func TestOIDCFilterCacheExpires(t *testing.T) {
+	f := &oidcFilter{cache: make(map[string]cachedToken)}
+	f.storeCache("tok", "alice", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := f.lookupCache("tok"); ok {
+		t.Error("expected an expired cache entry to be evicted")
+	}
+}
@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"bufio"
+	"net/http"
+	"time"
+
+	"github.com/frizz925/higuchi/internal/metrics"
+)
+
+type parseFilter struct {
+	filters   []HTTPFilter
+	collector metrics.Collector
+}
+
+// NewParseFilter returns the entry point of the filter chain: it parses
+// the incoming HTTP request off the connection and then runs filters in
+// order against it, recording each one's latency on collector.
+func NewParseFilter(collector metrics.Collector, filters ...HTTPFilter) HTTPFilter {
+	return &parseFilter{filters: filters, collector: collector}
+}
+
+func (f *parseFilter) Name() string { return "parse" }
+
+func (f *parseFilter) Filter(ctx *Context) (bool, error) {
+	if ctx.Reader == nil {
+		ctx.Reader = bufio.NewReader(ctx.Conn)
+	}
+	req, err := http.ReadRequest(ctx.Reader)
+	if err != nil {
+		return false, err
+	}
+	ctx.Request = req
+
+	for _, hf := range f.filters {
+		start := time.Now()
+		cont, err := hf.Filter(ctx)
+		f.collector.FilterLatency(hf.Name(), time.Since(start))
+		if err != nil || !cont {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/frizz925/higuchi/internal/metrics"
+	"go.uber.org/zap"
+)
+
+type tunnelFilter struct {
+	dispatch   *DispatchFilter
+	bufferSize int
+	collector  metrics.Collector
+}
+
+// NewTunnelFilter handles CONNECT requests by dialing the destination
+// through df and splicing the two connections together once the tunnel
+// is established.
+func NewTunnelFilter(df *DispatchFilter, bufferSize int, collector metrics.Collector) HTTPFilter {
+	return &tunnelFilter{dispatch: df, bufferSize: bufferSize, collector: collector}
+}
+
+func (f *tunnelFilter) Name() string { return "tunnel" }
+
+func (f *tunnelFilter) Filter(ctx *Context) (bool, error) {
+	req := ctx.Request
+	if req.Method != http.MethodConnect {
+		return true, nil
+	}
+
+	upstream, err := f.dispatch.Dial(req.Host)
+	if err != nil {
+		resp := &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Request:    req,
+		}
+		return false, resp.Write(ctx.Conn)
+	}
+	defer upstream.Close()
+
+	if _, err := ctx.Conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return false, err
+	}
+
+	logger := ctx.Logger
+	logger.Info("tunnel established", zap.String("host", req.Host))
+	start := time.Now()
+
+	f.collector.TunnelOpened()
+	defer f.collector.TunnelClosed()
+
+	var in, out int64
+	errCh := make(chan error, 2)
+	go func() {
+		n, err := copyTraced(upstream, ctx.Conn, make([]byte, f.bufferSize), logger, "up")
+		in = n
+		errCh <- err
+	}()
+	go func() {
+		n, err := copyTraced(ctx.Conn, upstream, make([]byte, f.bufferSize), logger, "down")
+		out = n
+		errCh <- err
+	}()
+	err = <-errCh
+	// Closing both ends unblocks whichever copy direction is still
+	// running, so the byte counts below are safe to read.
+	upstream.Close()
+	ctx.Conn.Close()
+	<-errCh
+	f.collector.BytesTransferred(ctx.Listener, in, out)
+
+	logger.Info("tunnel closed",
+		zap.String("host", req.Host),
+		zap.Int64("bytes_in", in),
+		zap.Int64("bytes_out", out),
+		zap.Duration("dur", time.Since(start)),
+	)
+	return false, err
+}
+
+// copyTraced behaves like io.CopyBuffer, except each chunk copied is
+// logged at debug level when enabled. The Check() guard keeps this from
+// allocating a field slice per chunk when debug logging is off, which
+// matters here since this loop runs once per TCP read for the lifetime
+// of the tunnel.
+func copyTraced(dst io.Writer, src io.Reader, buf []byte, logger *zap.Logger, direction string) (int64, error) {
+	var total int64
+	for {
+		nr, er := src.Read(buf)
+		if nr > 0 {
+			nw, ew := dst.Write(buf[:nr])
+			if nw > 0 {
+				total += int64(nw)
+			}
+			if ce := logger.Check(zap.DebugLevel, "tunnel chunk copied"); ce != nil {
+				ce.Write(zap.String("direction", direction), zap.Int("bytes", nw))
+			}
+			if ew != nil {
+				return total, ew
+			}
+			if nr != nw {
+				return total, io.ErrShortWrite
+			}
+		}
+		if er != nil {
+			if er == io.EOF {
+				er = nil
+			}
+			return total, er
+		}
+	}
+}
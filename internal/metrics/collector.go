@@ -0,0 +1,49 @@
+// Package metrics instruments the proxy: server.Server, worker.Worker
+// and each filter.* record activity through a Collector, which is
+// either a Prometheus collector exposed at /metrics or, in aggregate
+// mode, one that also pushes periodic snapshots to a configured
+// aggregator endpoint.
+package metrics
+
+import "time"
+
+// Collector records proxy activity. A nil Collector is never passed
+// around; callers that don't configure metrics get a NoopCollector
+// instead, so instrumentation call sites never need a nil check.
+type Collector interface {
+	// ConnectionOpened/ConnectionClosed track connections currently
+	// being handled, by listener address.
+	ConnectionOpened(listener string)
+	ConnectionClosed(listener string)
+
+	// BytesTransferred records bytes relayed between client and
+	// destination for a single connection.
+	BytesTransferred(listener string, in, out int64)
+
+	// RequestCompleted records a forwarded (non-CONNECT) request's
+	// outcome by status class, e.g. "2xx", "4xx".
+	RequestCompleted(statusClass string)
+
+	// AuthFailure records a rejected credential, regardless of scheme.
+	AuthFailure()
+
+	// TunnelOpened/TunnelClosed track CONNECT tunnels.
+	TunnelOpened()
+	TunnelClosed()
+
+	// FilterLatency records how long a single filter.HTTPFilter took
+	// to process a request.
+	FilterLatency(filterName string, d time.Duration)
+}
+
+// NoopCollector discards every metric.
+type NoopCollector struct{}
+
+func (NoopCollector) ConnectionOpened(string)              {}
+func (NoopCollector) ConnectionClosed(string)               {}
+func (NoopCollector) BytesTransferred(string, int64, int64) {}
+func (NoopCollector) RequestCompleted(string)               {}
+func (NoopCollector) AuthFailure()                          {}
+func (NoopCollector) TunnelOpened()                         {}
+func (NoopCollector) TunnelClosed()                         {}
+func (NoopCollector) FilterLatency(string, time.Duration)   {}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusCollector is an in-process Collector backed by a dedicated
+// prometheus.Registry, exposed at /metrics on the admin server.
+type PrometheusCollector struct {
+	registry *prometheus.Registry
+
+	activeConnections *prometheus.GaugeVec
+	bytesIn           *prometheus.CounterVec
+	bytesOut          *prometheus.CounterVec
+	requestsTotal     *prometheus.CounterVec
+	authFailures      prometheus.Counter
+	tunnelsOpen       prometheus.Gauge
+	tunnelsTotal      prometheus.Counter
+	filterLatency     *prometheus.HistogramVec
+}
+
+// NewPrometheusCollector builds a PrometheusCollector with its own
+// registry, so it never collides with metrics registered elsewhere in
+// the process.
+func NewPrometheusCollector() *PrometheusCollector {
+	c := &PrometheusCollector{
+		registry: prometheus.NewRegistry(),
+		activeConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "higuchi",
+			Name:      "active_connections",
+			Help:      "Connections currently being handled, by listener.",
+		}, []string{"listener"}),
+		bytesIn: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "higuchi",
+			Name:      "bytes_in_total",
+			Help:      "Bytes received from clients, by listener.",
+		}, []string{"listener"}),
+		bytesOut: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "higuchi",
+			Name:      "bytes_out_total",
+			Help:      "Bytes sent to clients, by listener.",
+		}, []string{"listener"}),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "higuchi",
+			Name:      "requests_total",
+			Help:      "Forwarded requests, by response status class.",
+		}, []string{"status_class"}),
+		authFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "higuchi",
+			Name:      "auth_failures_total",
+			Help:      "Authentication failures across all auth filters.",
+		}),
+		tunnelsOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "higuchi",
+			Name:      "tunnels_open",
+			Help:      "CONNECT tunnels currently open.",
+		}),
+		tunnelsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "higuchi",
+			Name:      "tunnels_total",
+			Help:      "CONNECT tunnels opened.",
+		}),
+		filterLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "higuchi",
+			Name:      "filter_latency_seconds",
+			Help:      "Time spent in each filter, by filter name.",
+		}, []string{"filter"}),
+	}
+	c.registry.MustRegister(
+		c.activeConnections,
+		c.bytesIn,
+		c.bytesOut,
+		c.requestsTotal,
+		c.authFailures,
+		c.tunnelsOpen,
+		c.tunnelsTotal,
+		c.filterLatency,
+	)
+	return c
+}
+
+func (c *PrometheusCollector) ConnectionOpened(listener string) {
+	c.activeConnections.WithLabelValues(listener).Inc()
+}
+
+func (c *PrometheusCollector) ConnectionClosed(listener string) {
+	c.activeConnections.WithLabelValues(listener).Dec()
+}
+
+func (c *PrometheusCollector) BytesTransferred(listener string, in, out int64) {
+	c.bytesIn.WithLabelValues(listener).Add(float64(in))
+	c.bytesOut.WithLabelValues(listener).Add(float64(out))
+}
+
+func (c *PrometheusCollector) RequestCompleted(statusClass string) {
+	c.requestsTotal.WithLabelValues(statusClass).Inc()
+}
+
+func (c *PrometheusCollector) AuthFailure() {
+	c.authFailures.Inc()
+}
+
+func (c *PrometheusCollector) TunnelOpened() {
+	c.tunnelsOpen.Inc()
+	c.tunnelsTotal.Inc()
+}
+
+func (c *PrometheusCollector) TunnelClosed() {
+	c.tunnelsOpen.Dec()
+}
+
+func (c *PrometheusCollector) FilterLatency(filterName string, d time.Duration) {
+	c.filterLatency.WithLabelValues(filterName).Observe(d.Seconds())
+}
+
+// Handler returns the http.Handler that serves this collector's metrics
+// in the Prometheus exposition format.
+func (c *PrometheusCollector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+)
+
+// Pusher periodically POSTs a PrometheusCollector's snapshot to an
+// aggregator endpoint, for deployments running many Higuchi instances
+// that want one aggregated view instead of scraping each instance.
+type Pusher struct {
+	collector *PrometheusCollector
+	endpoint  string
+	interval  time.Duration
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// NewPusher returns a Pusher that pushes collector's snapshot to
+// endpoint every interval.
+func NewPusher(collector *PrometheusCollector, endpoint string, interval time.Duration, logger *zap.Logger) *Pusher {
+	return &Pusher{
+		collector: collector,
+		endpoint:  endpoint,
+		interval:  interval,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		logger:    logger,
+	}
+}
+
+// Run pushes snapshots on Pusher's interval until ctx is canceled.
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.push(ctx); err != nil {
+				p.logger.Error("error while pushing metrics snapshot", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (p *Pusher) push(ctx context.Context) error {
+	families, err := p.collector.registry.Gather()
+	if err != nil {
+		return fmt.Errorf("error while gathering metrics: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return fmt.Errorf("error while encoding metrics snapshot: %v", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while posting metrics snapshot: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("aggregator responded with status %s", resp.Status)
+	}
+	return nil
+}
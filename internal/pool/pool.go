@@ -0,0 +1,34 @@
+// Package pool bounds the number of workers handling connections
+// concurrently by preallocating them and handing them out on demand.
+package pool
+
+import "github.com/frizz925/higuchi/internal/worker"
+
+// Pool hands out and reclaims workers.
+type Pool interface {
+	Get() *worker.Worker
+	Put(w *worker.Worker)
+}
+
+type preallocatedPool struct {
+	workers chan *worker.Worker
+}
+
+// NewPreallocatedPool builds size workers up front via factory and
+// returns a Pool that hands them out from a buffered channel, blocking
+// Get calls once all workers are checked out.
+func NewPreallocatedPool(factory func(num int) *worker.Worker, size int) Pool {
+	workers := make(chan *worker.Worker, size)
+	for i := 0; i < size; i++ {
+		workers <- factory(i)
+	}
+	return &preallocatedPool{workers: workers}
+}
+
+func (p *preallocatedPool) Get() *worker.Worker {
+	return <-p.workers
+}
+
+func (p *preallocatedPool) Put(w *worker.Worker) {
+	p.workers <- w
+}
@@ -0,0 +1,133 @@
+// Package server accepts connections on one or more listeners and hands
+// each one to a worker drawn from a pool.Pool.
+package server
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/frizz925/higuchi/internal/metrics"
+	"github.com/frizz925/higuchi/internal/pool"
+	"go.uber.org/zap"
+)
+
+// Config configures a Server.
+type Config struct {
+	Logger  *zap.Logger
+	Pool    pool.Pool
+	Metrics metrics.Collector
+}
+
+// Server accepts connections on its listeners and dispatches them to the
+// configured worker pool.
+type Server struct {
+	logger    *zap.Logger
+	collector metrics.Collector
+
+	poolMu sync.RWMutex
+	pool   pool.Pool
+
+	listenersMu sync.Mutex
+	listeners   []net.Listener
+
+	wg     sync.WaitGroup
+	active int64
+}
+
+// New returns a Server configured by cfg.
+func New(cfg Config) *Server {
+	collector := cfg.Metrics
+	if collector == nil {
+		collector = metrics.NoopCollector{}
+	}
+	return &Server{logger: cfg.Logger, pool: cfg.Pool, collector: collector}
+}
+
+// Listen starts accepting connections on network/addr and returns the
+// resulting listener.
+func (s *Server) Listen(network, addr string) (net.Listener, error) {
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	s.listenersMu.Lock()
+	s.listeners = append(s.listeners, l)
+	s.listenersMu.Unlock()
+
+	s.wg.Add(1)
+	go s.serve(l)
+	return l, nil
+}
+
+func (s *Server) serve(l net.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn, l.Addr().String())
+	}
+}
+
+func (s *Server) handle(conn net.Conn, listener string) {
+	atomic.AddInt64(&s.active, 1)
+	s.collector.ConnectionOpened(listener)
+	defer func() {
+		atomic.AddInt64(&s.active, -1)
+		s.collector.ConnectionClosed(listener)
+	}()
+
+	p := s.currentPool()
+	w := p.Get()
+	defer p.Put(w)
+	if err := w.Handle(conn, listener); err != nil {
+		s.logger.Error("error while handling connection", zap.Int("worker", w.Num), zap.Error(err))
+	}
+}
+
+// ActiveConnections returns the number of connections currently being handled.
+func (s *Server) ActiveConnections() int64 {
+	return atomic.LoadInt64(&s.active)
+}
+
+func (s *Server) currentPool() pool.Pool {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	return s.pool
+}
+
+// SetPool atomically swaps the worker pool used for new connections.
+// Connections already handed a worker from the previous pool finish with
+// it; only subsequent Accept calls see the new pool.
+func (s *Server) SetPool(p pool.Pool) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.pool = p
+}
+
+// Listeners returns the addresses currently being listened on.
+func (s *Server) Listeners() []net.Addr {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	addrs := make([]net.Addr, len(s.listeners))
+	for i, l := range s.listeners {
+		addrs[i] = l.Addr()
+	}
+	return addrs
+}
+
+// Close closes every listener and waits for their accept loops to exit.
+func (s *Server) Close() error {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	var firstErr error
+	for _, l := range s.listeners {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.wg.Wait()
+	return firstErr
+}
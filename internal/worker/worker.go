@@ -0,0 +1,70 @@
+// Package worker runs accepted connections through a filter.HTTPFilter
+// chain. Workers are pooled (see internal/pool) so the server can bound
+// the number of connections handled concurrently.
+package worker
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/frizz925/higuchi/internal/filter"
+	"github.com/frizz925/higuchi/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Worker handles one connection at a time through its filter chain.
+type Worker struct {
+	Num       int
+	filter    filter.HTTPFilter
+	collector metrics.Collector
+	logger    *zap.Logger
+}
+
+// New returns a Worker identified by num that runs connections through
+// f, recording its total handling time on collector and logging through
+// a logger scoped to each connection.
+func New(num int, f filter.HTTPFilter, collector metrics.Collector, logger *zap.Logger) *Worker {
+	return &Worker{Num: num, filter: f, collector: collector, logger: logger}
+}
+
+// Handle runs conn through the worker's filter chain, closing conn once
+// done. listener identifies which server listener accepted conn, for
+// per-listener metrics and logging.
+func (w *Worker) Handle(conn net.Conn, listener string) error {
+	defer conn.Close()
+	start := time.Now()
+
+	logger := w.logger.With(
+		zap.String("correlation_id", newCorrelationID()),
+		zap.String("remote", conn.RemoteAddr().String()),
+		zap.String("listener", listener),
+	)
+	ctx := &filter.Context{
+		Conn:     conn,
+		Reader:   bufio.NewReader(conn),
+		Listener: listener,
+		Logger:   logger,
+	}
+
+	_, err := w.filter.Filter(ctx)
+	dur := time.Since(start)
+	w.collector.FilterLatency("worker", dur)
+
+	if ce := logger.Check(zap.DebugLevel, "connection handled"); ce != nil {
+		ce.Write(zap.Duration("dur", dur), zap.Error(err))
+	}
+	return err
+}
+
+// newCorrelationID returns a short random hex id used to tie together
+// every log line emitted for a single connection.
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}